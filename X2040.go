@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/gousb"
@@ -27,6 +28,25 @@ type PertelianX2040 struct {
 	iface     *gousb.Interface
 	ep        *gousb.OutEndpoint
 	ifaceDone func()
+
+	// resilient is set by NewX2040Resilient. It enables the disconnect
+	// detection and background reconnect handled in resilient.go; plain
+	// NewX2040 handles leave this false and behave exactly as before.
+	resilient bool
+	ctx       *gousb.Context
+	vid, pid  gousb.ID
+
+	mu        sync.Mutex
+	connected bool
+	lightOn   bool
+	displayOn bool
+
+	cgram     [7]PertelianX2040Character
+	cgramUsed [7]bool
+	screen    *Screen
+
+	ready         chan struct{}
+	stateChangeFn []func(connected bool)
 }
 
 var (
@@ -52,8 +72,8 @@ var (
 )
 
 // NewX2040 instantiates a new PertelianX2040 for you to play with.
-func NewX2040(ctx *gousb.Context) (PertelianX2040, error) {
-	pert := PertelianX2040{}
+func NewX2040(ctx *gousb.Context) (*PertelianX2040, error) {
+	pert := &PertelianX2040{}
 
 	device, err := ctx.OpenDeviceWithVIDPID(0x0403, 0x6001)
 	if err != nil {
@@ -92,9 +112,19 @@ func (pert *PertelianX2040) Write(data []byte) (int, error) {
 		if i <= 2 {
 			time.Sleep(1 * time.Microsecond)
 		}
-		w, err := pert.ep.Write(data[i : i+1])
+		pert.mu.Lock()
+		ep := pert.ep
+		connected := !pert.resilient || pert.connected
+		pert.mu.Unlock()
+		if ep == nil || !connected {
+			return written, ErrX2040DeviceNotFound
+		}
+		w, err := ep.Write(data[i : i+1])
 		written += w
 		if err != nil {
+			if pert.resilient {
+				pert.handleDisconnect(err)
+			}
 			return written, err
 		}
 	}
@@ -173,12 +203,22 @@ func (pert *PertelianX2040) Close() error {
 
 // On turns on the display, initializes it, clears any data already on there and turns the light on.
 func (pert *PertelianX2040) On() error {
-	return pert.do(x2040On, x2040Init, x2040Clear, x2040LightOn)
+	err := pert.do(x2040On, x2040Init, x2040Clear, x2040LightOn)
+	if err == nil {
+		pert.displayOn = true
+		pert.lightOn = true
+	}
+	return err
 }
 
 // Off turns off the light, and then the display.
 func (pert *PertelianX2040) Off() error {
-	return pert.do(x2040LightOff, x2040Off)
+	err := pert.do(x2040LightOff, x2040Off)
+	if err == nil {
+		pert.displayOn = false
+		pert.lightOn = false
+	}
+	return err
 }
 
 // Clear removes all data visible on the display.
@@ -196,11 +236,16 @@ func (pert *PertelianX2040) Blank(line uint8) error {
 
 // Light sets the display light to the requested state.
 func (pert *PertelianX2040) Light(state bool) error {
+	var err error
 	if state {
-		return pert.inst(x2040LightOn)
+		err = pert.inst(x2040LightOn)
 	} else {
-		return pert.inst(x2040LightOff)
+		err = pert.inst(x2040LightOff)
 	}
+	if err == nil {
+		pert.lightOn = state
+	}
+	return err
 }
 
 // SetCharacter stores the given character in the display for later display.
@@ -214,6 +259,11 @@ func (pert *PertelianX2040) SetCharacter(position uint8, char PertelianX2040Char
 	output := []byte{x2040Command, position}
 	output = append(output, char.Lines[0:8]...)
 	_, err := pert.Write(output)
+	if err == nil {
+		slot := position/8 - 9
+		pert.cgram[slot] = char
+		pert.cgramUsed[slot] = true
+	}
 	return err
 }
 
@@ -0,0 +1,256 @@
+package pertelian
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// canvasWidth and canvasHeight are the effective pixel dimensions of the
+	// display: 20 columns * 5 pixels wide, 4 rows * 8 pixels tall.
+	canvasWidth  = canvasCols * cellWidth
+	canvasHeight = canvasRows * cellHeight
+
+	canvasCols = 20
+	canvasRows = 4
+
+	cellWidth  = 5
+	cellHeight = charSize
+)
+
+// cellBitmap is the 5x8 dot pattern for a single character cell, stored the
+// same way PertelianX2040Character.Lines is: one byte per row, dot j set at
+// bit (4-j).
+type cellBitmap [charSize]byte
+
+// asciiFallbacks lists the built-in display characters used to approximate a
+// cell's bitmap when there is no CGRAM slot left for it, in the order they
+// should be preferred when multiple are equally close.
+var asciiFallbacks = []struct {
+	char    byte
+	pattern cellBitmap
+}{
+	{' ', cellBitmap{0, 0, 0, 0, 0, 0, 0, 0}},
+	{'#', cellBitmap{0x1f, 0x1f, 0x1f, 0x1f, 0x1f, 0x1f, 0x1f, 0x1f}},
+	{'-', cellBitmap{0, 0, 0, 0x1f, 0, 0, 0, 0}},
+	{'|', cellBitmap{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}},
+	{'.', cellBitmap{0, 0, 0, 0, 0, 0, 0, 0x04}},
+	{'_', cellBitmap{0, 0, 0, 0, 0, 0, 0, 0x1f}},
+	{'*', cellBitmap{0, 0x0a, 0x1f, 0x0e, 0x1f, 0x0a, 0, 0}},
+}
+
+// PertelianX2040Canvas adapts the display's 20x4 character grid into a
+// 100x32 pixel image.Image / draw.Image, so callers can render with the
+// standard library's image/draw or golang.org/x/image/font and get a
+// best-effort rendering on the LCD via CGRAM auto-allocation.
+type PertelianX2040Canvas struct {
+	pert *PertelianX2040
+
+	pix [canvasHeight][canvasWidth]bool
+
+	primed    bool
+	slots     [7]cellBitmap
+	slotsUsed [7]bool
+	glyph     [canvasRows][canvasCols]byte
+}
+
+// NewCanvas wraps pert in a PertelianX2040Canvas ready to be drawn on.
+// Nothing is sent to the display until Flush is called.
+func NewCanvas(pert *PertelianX2040) *PertelianX2040Canvas {
+	return &PertelianX2040Canvas{pert: pert}
+}
+
+// ColorModel implements image.Image. Every pixel is either lit or dark.
+func (c *PertelianX2040Canvas) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+// Bounds implements image.Image.
+func (c *PertelianX2040Canvas) Bounds() image.Rectangle {
+	return image.Rect(0, 0, canvasWidth, canvasHeight)
+}
+
+// At implements image.Image.
+func (c *PertelianX2040Canvas) At(x, y int) color.Color {
+	if x < 0 || x >= canvasWidth || y < 0 || y >= canvasHeight {
+		return color.Gray{Y: 0}
+	}
+	if c.pix[y][x] {
+		return color.Gray{Y: 0xff}
+	}
+	return color.Gray{Y: 0}
+}
+
+// Set implements draw.Image. A color whose gray value is above 0x7f turns
+// the pixel on; any darker color turns it off.
+func (c *PertelianX2040Canvas) Set(x, y int, col color.Color) {
+	if x < 0 || x >= canvasWidth || y < 0 || y >= canvasHeight {
+		return
+	}
+	gray := color.GrayModel.Convert(col).(color.Gray)
+	c.pix[y][x] = gray.Y > 0x7f
+}
+
+// cellAt computes the 5x8 bitmap for the cell at the given row/col.
+func (c *PertelianX2040Canvas) cellAt(row, col int) cellBitmap {
+	var bitmap cellBitmap
+	for dy := 0; dy < cellHeight; dy++ {
+		y := row*cellHeight + dy
+		var line byte
+		for dx := 0; dx < cellWidth; dx++ {
+			x := col*cellWidth + dx
+			if c.pix[y][x] {
+				line |= 1 << (4 - dx)
+			}
+		}
+		bitmap[dy] = line
+	}
+	return bitmap
+}
+
+// hammingDistance counts the number of differing dots between two 5x8
+// bitmaps (40 bits total).
+func hammingDistance(a, b cellBitmap) int {
+	distance := 0
+	for i := 0; i < charSize; i++ {
+		distance += bits.OnesCount8((a[i] ^ b[i]) & 0x1f)
+	}
+	return distance
+}
+
+// patternCount tracks how many cells on the grid want a given non-blank
+// pattern, along with the first cell index it was seen at so slot
+// assignment stays deterministic across ties.
+type patternCount struct {
+	pattern   cellBitmap
+	count     int
+	firstSeen int
+}
+
+// Flush computes the current 80-cell bitmap, allocates the 7 CGRAM slots to
+// the most-used non-blank patterns, falls back to the closest ASCII
+// character or already-allocated glyph for everything else, and sends only
+// the slots and cells that changed since the last Flush.
+func (c *PertelianX2040Canvas) Flush() error {
+	var blank cellBitmap
+
+	cells := make([]cellBitmap, canvasRows*canvasCols)
+	counts := make(map[cellBitmap]*patternCount)
+	var order []cellBitmap
+
+	for row := 0; row < canvasRows; row++ {
+		for col := 0; col < canvasCols; col++ {
+			index := row*canvasCols + col
+			bitmap := c.cellAt(row, col)
+			cells[index] = bitmap
+			if bitmap == blank {
+				continue
+			}
+			if pc, ok := counts[bitmap]; ok {
+				pc.count++
+			} else {
+				counts[bitmap] = &patternCount{pattern: bitmap, count: 1, firstSeen: index}
+				order = append(order, bitmap)
+			}
+		}
+	}
+
+	unique := make([]*patternCount, 0, len(order))
+	for _, pattern := range order {
+		unique = append(unique, counts[pattern])
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		if unique[i].count != unique[j].count {
+			return unique[i].count > unique[j].count
+		}
+		return unique[i].firstSeen < unique[j].firstSeen
+	})
+
+	var newSlots [7]cellBitmap
+	var newSlotsUsed [7]bool
+	slotOf := make(map[cellBitmap]uint8, 7)
+	for i := 0; i < len(unique) && i < 7; i++ {
+		newSlots[i] = unique[i].pattern
+		newSlotsUsed[i] = true
+		slotOf[unique[i].pattern] = uint8(i)
+	}
+
+	var newGlyph [canvasRows][canvasCols]byte
+	for row := 0; row < canvasRows; row++ {
+		for col := 0; col < canvasCols; col++ {
+			bitmap := cells[row*canvasCols+col]
+			if bitmap == blank {
+				newGlyph[row][col] = ' '
+				continue
+			}
+			if slot, ok := slotOf[bitmap]; ok {
+				newGlyph[row][col] = slot + 1
+				continue
+			}
+			newGlyph[row][col] = closestGlyph(bitmap, newSlots, newSlotsUsed)
+		}
+	}
+
+	for slot := 0; slot < 7; slot++ {
+		if !newSlotsUsed[slot] {
+			continue
+		}
+		if c.primed && c.slotsUsed[slot] && c.slots[slot] == newSlots[slot] {
+			continue
+		}
+		if err := c.pert.SetCharacter(uint8(slot), PertelianX2040Character{Lines: newSlots[slot]}); err != nil {
+			return err
+		}
+	}
+
+	for row := 0; row < canvasRows; row++ {
+		col := 0
+		for col < canvasCols {
+			if c.primed && newGlyph[row][col] == c.glyph[row][col] {
+				col++
+				continue
+			}
+			start := col
+			run := make([]byte, 0, canvasCols-col)
+			for col < canvasCols && (!c.primed || newGlyph[row][col] != c.glyph[row][col]) {
+				run = append(run, newGlyph[row][col])
+				col++
+			}
+			if err := c.pert.PrintAt(uint8(row), uint8(start), string(run)); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.slots = newSlots
+	c.slotsUsed = newSlotsUsed
+	c.glyph = newGlyph
+	c.primed = true
+	return nil
+}
+
+// closestGlyph finds the best available stand-in for a bitmap that didn't
+// get its own CGRAM slot: the nearest ASCII fallback or the nearest
+// currently-allocated CGRAM glyph, by Hamming distance over the 40 dots.
+func closestGlyph(bitmap cellBitmap, slots [7]cellBitmap, slotsUsed [7]bool) byte {
+	best := asciiFallbacks[0].char
+	bestDistance := hammingDistance(bitmap, asciiFallbacks[0].pattern)
+	for _, fallback := range asciiFallbacks[1:] {
+		if d := hammingDistance(bitmap, fallback.pattern); d < bestDistance {
+			best = fallback.char
+			bestDistance = d
+		}
+	}
+	for slot := 0; slot < 7; slot++ {
+		if !slotsUsed[slot] {
+			continue
+		}
+		if d := hammingDistance(bitmap, slots[slot]); d < bestDistance {
+			best = byte(slot) + 1
+			bestDistance = d
+		}
+	}
+	return best
+}
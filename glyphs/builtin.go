@@ -0,0 +1,19 @@
+package glyphs
+
+import "embed"
+
+//go:embed assets/builtin.chr
+var builtinFS embed.FS
+
+// Builtin is the bundled glyph pack: the line-drawing set used by
+// PertelianX2040.SetLineDrawingCharacters, a set of arrows, and spinner
+// frames, ready to Apply to any display.
+var Builtin *GlyphSet
+
+func init() {
+	set, err := LoadGlyphs(builtinFS, "assets/*.chr")
+	if err != nil {
+		panic(err)
+	}
+	Builtin = set
+}
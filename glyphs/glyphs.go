@@ -0,0 +1,210 @@
+// Package glyphs loads sets of custom Pertelian X2040 characters from a
+// simple text format, so users can define and extend their own CGRAM
+// glyphs with data files instead of Go source.
+package glyphs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/DemmyDemon/pertelian"
+)
+
+var (
+	// ErrGlyphWant8Lines is returned when a glyph block does not contain
+	// exactly 8 pattern lines.
+	ErrGlyphWant8Lines = errors.New("glyph must be made up of exactly 8 lines")
+
+	// ErrGlyphWidth5 is returned when a glyph pattern line is not exactly 5
+	// columns wide.
+	ErrGlyphWidth5 = errors.New("glyph lines must be exactly 5 columns wide")
+
+	// ErrGlyphUnknownChar is returned when a glyph pattern line contains a
+	// character other than '#', '.', or space.
+	ErrGlyphUnknownChar = errors.New("glyph lines may only contain '#', '.', or space")
+
+	// ErrGlyphNotFound is returned by Apply when a requested glyph name
+	// isn't in the GlyphSet, and by Compose when a name has not been
+	// assigned a slot by the most recent Apply.
+	ErrGlyphNotFound = errors.New("glyph not found")
+
+	// ErrTooManyGlyphs is returned by Apply when more than 7 slots are
+	// requested at once.
+	ErrTooManyGlyphs = errors.New("at most 7 glyphs can be assigned at once")
+)
+
+// GlyphSet is a named collection of custom characters loaded with
+// LoadGlyphs.
+type GlyphSet struct {
+	chars []pertelian.PertelianX2040Character
+	names map[string]int
+
+	// applied records which slot each name currently occupies, as of the
+	// most recent call to Apply. Compose reads from this rather than from
+	// names, since a glyph's CGRAM slot depends on the order it was passed
+	// to Apply, not on its position in the loaded catalog.
+	applied map[string]uint8
+}
+
+// LoadGlyphs reads every file in fsys matching glob and parses them as
+// glyph definitions: blocks of 8 lines of 5 columns using '#' for a lit dot
+// and '.' or space for a dark one, separated by blank lines, each
+// optionally preceded by a "name: foo" header line. Unnamed blocks are
+// named by file name without its extension and their position, e.g.
+// "file#0" for the first block of "file.chr".
+func LoadGlyphs(fsys fs.FS, glob string) (*GlyphSet, error) {
+	matches, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return nil, fmt.Errorf("glob %q: %w", glob, err)
+	}
+
+	set := &GlyphSet{names: map[string]int{}}
+	for _, match := range matches {
+		data, err := fs.ReadFile(fsys, match)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", match, err)
+		}
+		blocks, err := parseGlyphFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse %q: %w", match, err)
+		}
+		base := strings.TrimSuffix(path.Base(match), path.Ext(match))
+		for i, block := range blocks {
+			name := block.name
+			if name == "" {
+				name = fmt.Sprintf("%s#%d", base, i)
+			}
+			set.names[name] = len(set.chars)
+			set.chars = append(set.chars, block.char)
+		}
+	}
+	return set, nil
+}
+
+// namedGlyph is a single parsed glyph block, with its optional name.
+type namedGlyph struct {
+	name string
+	char pertelian.PertelianX2040Character
+}
+
+// parseGlyphFile splits data into blank-line-separated blocks and parses
+// each one as a named glyph.
+func parseGlyphFile(data []byte) ([]namedGlyph, error) {
+	var glyphs []namedGlyph
+	var name string
+	var rows []string
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		char, err := parseGlyphRows(rows)
+		if err != nil {
+			return err
+		}
+		glyphs = append(glyphs, namedGlyph{name: name, char: char})
+		name = ""
+		rows = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// An empty line separates blocks. A line of 5 spaces is a valid,
+		// entirely-dark pattern row, so only a truly empty line counts.
+		if line == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(rows) == 0 && name == "" {
+			if header, ok := strings.CutPrefix(line, "name:"); ok {
+				name = strings.TrimSpace(header)
+				continue
+			}
+		}
+		rows = append(rows, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return glyphs, nil
+}
+
+// parseGlyphRows turns 8 lines of '#'/'.'/space into a
+// PertelianX2040Character, by way of pertelian.NewX2040Char.
+func parseGlyphRows(rows []string) (pertelian.PertelianX2040Character, error) {
+	if len(rows) != 8 {
+		return pertelian.PertelianX2040Character{}, fmt.Errorf("%w: got %d", ErrGlyphWant8Lines, len(rows))
+	}
+	lines := make([]string, 8)
+	for i, row := range rows {
+		if len(row) != 5 {
+			return pertelian.PertelianX2040Character{}, fmt.Errorf("%w: %q", ErrGlyphWidth5, row)
+		}
+		line := []byte(row)
+		for j, r := range line {
+			switch r {
+			case '#':
+				// filled dot, leave as-is for NewX2040Char
+			case '.', ' ':
+				line[j] = ' '
+			default:
+				return pertelian.PertelianX2040Character{}, fmt.Errorf("%w: %q", ErrGlyphUnknownChar, row)
+			}
+		}
+		lines[i] = string(line)
+	}
+	char, err := pertelian.NewX2040Char(lines...)
+	if err != nil {
+		return pertelian.PertelianX2040Character{}, err
+	}
+	return char, nil
+}
+
+// Apply assigns up to 7 named glyphs to CGRAM slots 0-6, in the order
+// given, and records that assignment so Compose can refer to these names
+// afterwards.
+func (gs *GlyphSet) Apply(pert *pertelian.PertelianX2040, slots ...string) error {
+	if len(slots) > 7 {
+		return ErrTooManyGlyphs
+	}
+	applied := make(map[string]uint8, len(slots))
+	for i, name := range slots {
+		index, ok := gs.names[name]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrGlyphNotFound, name)
+		}
+		if err := pert.SetCharacter(uint8(i), gs.chars[index]); err != nil {
+			return err
+		}
+		applied[name] = uint8(i)
+	}
+	gs.applied = applied
+	return nil
+}
+
+// Compose returns the byte string that will display the named glyphs when
+// passed to Print or PrintAt, using the slot each name was assigned by the
+// most recent call to Apply.
+func (gs *GlyphSet) Compose(names ...string) (string, error) {
+	output := make([]byte, len(names))
+	for i, name := range names {
+		slot, ok := gs.applied[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %q has not been applied to a slot", ErrGlyphNotFound, name)
+		}
+		output[i] = slot + 1
+	}
+	return string(output), nil
+}
@@ -0,0 +1,37 @@
+package glyphs
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+const blankGlyph = ".....\n.....\n.....\n.....\n.....\n.....\n.....\n.....\n"
+
+func TestComposeUsesAppliedSlotNotCatalogIndex(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pack.chr": &fstest.MapFile{
+			Data: []byte("name: a\n" + blankGlyph + "\nname: b\n" + blankGlyph + "\nname: c\n" + blankGlyph),
+		},
+	}
+
+	set, err := LoadGlyphs(fsys, "*.chr")
+	if err != nil {
+		t.Fatalf("LoadGlyphs: %v", err)
+	}
+
+	// "c" sits at catalog index 2, but here it's applied to slot 0 and "a"
+	// to slot 1 - Compose must reflect that, not the catalog index.
+	set.applied = map[string]uint8{"c": 0, "a": 1}
+
+	got, err := set.Compose("c", "a")
+	if err != nil {
+		t.Fatalf("Compose(\"c\", \"a\"): %v", err)
+	}
+	if want := string([]byte{1, 2}); got != want {
+		t.Errorf("Compose(\"c\", \"a\") = %q, want %q", got, want)
+	}
+
+	if _, err := set.Compose("b"); err == nil {
+		t.Error("Compose(\"b\") should fail: b was never applied to a slot")
+	}
+}
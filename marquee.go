@@ -0,0 +1,273 @@
+package pertelian
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MarqueeMode selects how a Marquee moves its text across the display.
+type MarqueeMode int
+
+const (
+	// ScrollLeft continuously scrolls text leftward, wrapping around after
+	// Gap blank columns.
+	ScrollLeft MarqueeMode = iota
+	// ScrollRight continuously scrolls text rightward, wrapping around
+	// after Gap blank columns.
+	ScrollRight
+	// Bounce slides the text back and forth between its ends, optionally
+	// pausing at each end.
+	Bounce
+)
+
+// MarqueeOptions configures a Marquee.
+type MarqueeOptions struct {
+	Mode MarqueeMode
+
+	// Interval is how often the window advances by one column. Defaults to
+	// 300ms if zero or negative.
+	Interval time.Duration
+
+	// Gap is how many blank columns separate the end of the text from its
+	// own start when ScrollLeft or ScrollRight wraps around.
+	Gap int
+
+	// PauseAtEnds is how long a Bounce Marquee waits at each end before
+	// reversing direction. Ignored by ScrollLeft and ScrollRight.
+	PauseAtEnds time.Duration
+}
+
+// Marquee scrolls or bounces a string of arbitrary length through the 20
+// visible columns of a single display line. Text of 20 characters or fewer
+// does not move in any mode: it is shown left-aligned, padded with spaces.
+type Marquee struct {
+	pert *PertelianX2040
+	line uint8
+	text string
+	opts MarqueeOptions
+
+	pos         int
+	dir         int
+	pausedUntil time.Time
+}
+
+// NewMarquee builds a Marquee that will write to the given line of pert.
+func NewMarquee(pert *PertelianX2040, line uint8, text string, opts MarqueeOptions) *Marquee {
+	if opts.Interval <= 0 {
+		opts.Interval = 300 * time.Millisecond
+	}
+	return &Marquee{pert: pert, line: line, text: text, opts: opts, dir: 1}
+}
+
+// window computes the 20-character slice of text currently visible.
+func (m *Marquee) window() string {
+	const width = 20
+	if len(m.text) <= width {
+		return m.text + strings.Repeat(" ", width-len(m.text))
+	}
+	switch m.opts.Mode {
+	case ScrollRight, ScrollLeft:
+		loop := m.text + strings.Repeat(" ", m.opts.Gap)
+		doubled := loop + loop
+		start := m.pos % len(loop)
+		return doubled[start : start+width]
+	default: // Bounce
+		return m.text[m.pos : m.pos+width]
+	}
+}
+
+// advance moves pos (and, for Bounce, dir) on to the next frame.
+func (m *Marquee) advance() {
+	const width = 20
+	if len(m.text) <= width {
+		return
+	}
+	switch m.opts.Mode {
+	case ScrollLeft:
+		loop := len(m.text) + m.opts.Gap
+		m.pos = (m.pos + 1) % loop
+	case ScrollRight:
+		loop := len(m.text) + m.opts.Gap
+		m.pos = (m.pos - 1 + loop) % loop
+	default: // Bounce
+		maxPos := len(m.text) - width
+		next := m.pos + m.dir
+		if next < 0 || next > maxPos {
+			m.dir = -m.dir
+			if m.opts.PauseAtEnds > 0 {
+				m.pausedUntil = time.Now().Add(m.opts.PauseAtEnds)
+			}
+			return
+		}
+		m.pos = next
+	}
+}
+
+// paused reports whether a Bounce Marquee is currently waiting at an end.
+func (m *Marquee) paused() bool {
+	return !m.pausedUntil.IsZero() && time.Now().Before(m.pausedUntil)
+}
+
+// tick advances the marquee by one frame (unless paused) and writes the new
+// window to the display. It's the unit of work shared by Start's own
+// ticker and by an Animator driving several marquees off one goroutine.
+func (m *Marquee) tick() error {
+	if m.paused() {
+		return nil
+	}
+	m.advance()
+	return m.pert.PrintAt(m.line, 0, m.window())
+}
+
+// Start writes the initial window and then runs a goroutine that ticks on
+// m.opts.Interval until ctx is done, writing only to m's own line.
+func (m *Marquee) Start(ctx context.Context) error {
+	if err := m.pert.PrintAt(m.line, 0, m.window()); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(m.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.tick()
+			}
+		}
+	}()
+	return nil
+}
+
+// Spinner rotates a fixed CGRAM slot through a sequence of pre-loaded
+// frames, for e.g. a busy indicator. The frames are passed to NewSpinner,
+// and each tick uploads the next one to the slot with SetCharacter.
+type Spinner struct {
+	pert     *PertelianX2040
+	slot     uint8
+	interval time.Duration
+	frames   []PertelianX2040Character
+	index    int
+}
+
+// NewSpinner builds a Spinner that rotates frames into the given CGRAM
+// slot (0-6) every interval. Defaults to 300ms if interval is zero or
+// negative.
+func NewSpinner(pert *PertelianX2040, slot uint8, interval time.Duration, frames ...PertelianX2040Character) *Spinner {
+	if interval <= 0 {
+		interval = 300 * time.Millisecond
+	}
+	return &Spinner{pert: pert, slot: slot, interval: interval, frames: frames}
+}
+
+// tick uploads the next frame to the spinner's CGRAM slot.
+func (s *Spinner) tick() error {
+	if len(s.frames) == 0 {
+		return nil
+	}
+	err := s.pert.SetCharacter(s.slot, s.frames[s.index])
+	s.index = (s.index + 1) % len(s.frames)
+	return err
+}
+
+// Start runs a goroutine that ticks on s.interval until ctx is done.
+func (s *Spinner) Start(ctx context.Context) error {
+	if err := s.tick(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.tick()
+			}
+		}
+	}()
+	return nil
+}
+
+// animEntry is an animation driven by an Animator rather than its own
+// ticker: elapsed accumulates base ticks until it reaches interval, at
+// which point tick fires and the remainder carries over.
+type animEntry struct {
+	interval time.Duration
+	elapsed  time.Duration
+	tick     func() error
+}
+
+// Animator multiplexes several Marquees and Spinners onto a single
+// goroutine and a single ticker, so they don't fight each other for the
+// display's one USB endpoint.
+type Animator struct {
+	base    time.Duration
+	entries []*animEntry
+}
+
+// NewAnimator builds an Animator that drives its entries on a base tick of
+// the given resolution; it should be no coarser than the shortest interval
+// among the Marquees and Spinners added to it. Defaults to 50ms if base is
+// zero or negative.
+func NewAnimator(base time.Duration) *Animator {
+	if base <= 0 {
+		base = 50 * time.Millisecond
+	}
+	return &Animator{base: base}
+}
+
+// AddMarquee puts m under the Animator's control. Do not also call
+// m.Start.
+func (a *Animator) AddMarquee(m *Marquee) {
+	first := true
+	tick := func() error {
+		if first {
+			// Like Marquee.Start, show the initial window before advancing.
+			first = false
+			return m.pert.PrintAt(m.line, 0, m.window())
+		}
+		return m.tick()
+	}
+	a.entries = append(a.entries, &animEntry{interval: m.opts.Interval, tick: tick})
+}
+
+// AddSpinner puts s under the Animator's control. Do not also call
+// s.Start.
+func (a *Animator) AddSpinner(s *Spinner) {
+	a.entries = append(a.entries, &animEntry{interval: s.interval, tick: s.tick})
+}
+
+// Start writes every entry's initial frame and then runs a goroutine that
+// ticks on the Animator's base interval until ctx is done, firing each
+// entry's tick whenever its own interval has elapsed.
+func (a *Animator) Start(ctx context.Context) error {
+	for _, entry := range a.entries {
+		if err := entry.tick(); err != nil {
+			return err
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(a.base)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, entry := range a.entries {
+					entry.elapsed += a.base
+					if entry.elapsed < entry.interval {
+						continue
+					}
+					entry.elapsed -= entry.interval
+					entry.tick()
+				}
+			}
+		}
+	}()
+	return nil
+}
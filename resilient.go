@@ -0,0 +1,209 @@
+package pertelian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+const (
+	reconnectInitialDelay = 500 * time.Millisecond
+	reconnectMaxDelay     = 30 * time.Second
+)
+
+// NewX2040Resilient instantiates a PertelianX2040 the same way NewX2040
+// does, but keeps hold of the gousb.Context and VID/PID so that if the
+// device is unplugged, it can be reopened automatically. Any Write or inst
+// failure marks the handle disconnected and starts a background goroutine
+// that retries OpenDeviceWithVIDPID with backoff until the device reappears,
+// then replays On, every cached SetCharacter slot, and the last Screen
+// buffer (if UseScreen was called).
+func NewX2040Resilient(ctx *gousb.Context) (*PertelianX2040, error) {
+	pert := &PertelianX2040{
+		resilient: true,
+		ctx:       ctx,
+		vid:       0x0403,
+		pid:       0x6001,
+		ready:     make(chan struct{}),
+	}
+
+	if err := pert.open(); err != nil {
+		return pert, err
+	}
+	pert.connected = true
+	close(pert.ready)
+	return pert, nil
+}
+
+// open acquires the device, interface, and endpoint, replacing whatever the
+// handle currently holds.
+func (pert *PertelianX2040) open() error {
+	device, err := pert.ctx.OpenDeviceWithVIDPID(pert.vid, pert.pid)
+	if err != nil {
+		return fmt.Errorf("obtain device: %w", err)
+	}
+	if device == nil {
+		return ErrX2040DeviceNotFound
+	}
+
+	iface, done, err := device.DefaultInterface()
+	if err != nil {
+		device.Close()
+		return fmt.Errorf("default interface: %w", err)
+	}
+
+	ep, err := iface.OutEndpoint(2)
+	if err != nil {
+		done()
+		device.Close()
+		return fmt.Errorf("open endpoint: %w", err)
+	}
+
+	pert.mu.Lock()
+	pert.device = device
+	pert.iface = iface
+	pert.ifaceDone = done
+	pert.ep = ep
+	pert.mu.Unlock()
+	return nil
+}
+
+// UseScreen associates a Screen with this handle so its buffer can be
+// replayed after a reconnect. It is only meaningful for handles created with
+// NewX2040Resilient.
+func (pert *PertelianX2040) UseScreen(screen *Screen) {
+	pert.mu.Lock()
+	pert.screen = screen
+	pert.mu.Unlock()
+}
+
+// OnStateChange registers a callback that is invoked whenever the handle
+// transitions between connected and disconnected.
+func (pert *PertelianX2040) OnStateChange(fn func(connected bool)) {
+	pert.mu.Lock()
+	pert.stateChangeFn = append(pert.stateChangeFn, fn)
+	pert.mu.Unlock()
+}
+
+// Wait blocks until the display is connected and ready, or ctx is done.
+func (pert *PertelianX2040) Wait(ctx context.Context) error {
+	pert.mu.Lock()
+	ready := pert.ready
+	pert.mu.Unlock()
+
+	if ready == nil {
+		// Handles from NewX2040 do not track connection state.
+		return nil
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleDisconnect marks the handle as disconnected and, if it isn't
+// already trying to reconnect, starts the background retry loop.
+func (pert *PertelianX2040) handleDisconnect(err error) {
+	pert.mu.Lock()
+	if !pert.connected {
+		pert.mu.Unlock()
+		return
+	}
+	pert.connected = false
+	pert.ready = make(chan struct{})
+	callbacks := append([]func(bool){}, pert.stateChangeFn...)
+	pert.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(false)
+	}
+
+	go pert.reconnectLoop()
+}
+
+// reconnectLoop releases the old device handle exactly once, then retries
+// opening a new one with exponential backoff until it succeeds, and
+// finally replays the cached state.
+func (pert *PertelianX2040) reconnectLoop() {
+	pert.mu.Lock()
+	ifaceDone := pert.ifaceDone
+	device := pert.device
+	pert.iface, pert.ifaceDone, pert.device, pert.ep = nil, nil, nil, nil
+	pert.mu.Unlock()
+
+	if ifaceDone != nil {
+		ifaceDone()
+	}
+	if device != nil {
+		device.Close()
+	}
+
+	delay := reconnectInitialDelay
+	for {
+		if err := pert.open(); err != nil {
+			time.Sleep(delay)
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+
+		// Write refuses to send while the handle is disconnected, so mark it
+		// connected before replaying.
+		pert.mu.Lock()
+		pert.connected = true
+		ready := pert.ready
+		pert.mu.Unlock()
+
+		pert.replay()
+
+		pert.mu.Lock()
+		close(ready)
+		if pert.ready != ready {
+			// A write during replay failed and handleDisconnect has already
+			// started a new reconnectLoop with a fresh ready channel.
+			pert.mu.Unlock()
+			return
+		}
+		callbacks := append([]func(bool){}, pert.stateChangeFn...)
+		pert.mu.Unlock()
+
+		for _, fn := range callbacks {
+			fn(true)
+		}
+		return
+	}
+}
+
+// replay pushes the cached display state back to a freshly reopened device:
+// power/light state, every allocated CGRAM slot, and the last Screen buffer
+// if one was attached with UseScreen.
+func (pert *PertelianX2040) replay() {
+	if pert.displayOn {
+		pert.do(x2040On, x2040Init, x2040Clear)
+	}
+	for slot := 0; slot < 7; slot++ {
+		if pert.cgramUsed[slot] {
+			pert.SetCharacter(uint8(slot), pert.cgram[slot])
+		}
+	}
+	if pert.lightOn {
+		pert.inst(x2040LightOn)
+	} else {
+		pert.inst(x2040LightOff)
+	}
+
+	pert.mu.Lock()
+	screen := pert.screen
+	pert.mu.Unlock()
+	if screen != nil {
+		screen.primed = false
+		screen.Flush(pert)
+	}
+}
@@ -0,0 +1,147 @@
+package pertelian
+
+// Screen is an in-memory mirror of the display: a 20x4 byte buffer plus a
+// shadow of the 7 CGRAM slots. Its methods mirror the direct PertelianX2040
+// API but only mutate the buffer; nothing reaches the device until Flush is
+// called, which diffs the buffer against what was last sent and writes only
+// the cells and slots that actually changed.
+type Screen struct {
+	buffer [canvasRows][canvasCols]byte
+
+	slots     [7]PertelianX2040Character
+	slotsUsed [7]bool
+
+	flushedBuffer [canvasRows][canvasCols]byte
+	flushedSlots  [7]PertelianX2040Character
+	flushedUsed   [7]bool
+	primed        bool
+}
+
+// NewScreen returns a Screen with its buffer blanked out, ready to be drawn
+// on and Flushed.
+func NewScreen() *Screen {
+	screen := &Screen{}
+	screen.Clear()
+	return screen
+}
+
+// SetAt stores a single raw byte at the given line and column in the
+// buffer.
+func (s *Screen) SetAt(line, col uint8, b byte) error {
+	if line > 3 {
+		return ErrX2040OutOfRange
+	}
+	if col > 19 {
+		return ErrX2040OutOfRange
+	}
+	s.buffer[line][col] = b
+	return nil
+}
+
+// PrintAt stores textString into the buffer starting at the given line and
+// column.
+func (s *Screen) PrintAt(line, col uint8, textString string) error {
+	text := []byte(textString)
+	if line > 3 {
+		return ErrX2040OutOfRange
+	}
+	if col > 19 {
+		return ErrX2040OutOfRange
+	}
+	if len(text) > 20 {
+		return ErrX2040OutOfRange
+	}
+	if col+uint8(len(text)) > 20 {
+		return ErrX2040OutOfRange
+	}
+	for i, b := range text {
+		s.buffer[line][int(col)+i] = b
+	}
+	return nil
+}
+
+// Centered stores text into the buffer so it's centered on the given line.
+func (s *Screen) Centered(line uint8, text string) error {
+	if len(text) > 20 {
+		return ErrX2040OutOfRange
+	}
+	offset := uint8((20 - len(text)) / 2)
+	return s.PrintAt(line, offset, text)
+}
+
+// Blank overwrites the given line in the buffer with all spaces.
+func (s *Screen) Blank(line uint8) error {
+	if line > 3 {
+		return ErrX2040OutOfRange
+	}
+	for col := 0; col < 20; col++ {
+		s.buffer[line][col] = ' '
+	}
+	return nil
+}
+
+// Clear blanks the whole buffer.
+func (s *Screen) Clear() {
+	for line := 0; line < canvasRows; line++ {
+		for col := 0; col < canvasCols; col++ {
+			s.buffer[line][col] = ' '
+		}
+	}
+}
+
+// SetCharacter stores a custom character in the Screen's CGRAM shadow. You
+// get 7 slots, 0-6, same as the device.
+func (s *Screen) SetCharacter(position uint8, char PertelianX2040Character) error {
+	if position > 6 {
+		return ErrX2040InvalidCharacterPosition
+	}
+	s.slots[position] = char
+	s.slotsUsed[position] = true
+	return nil
+}
+
+// Flush reconciles the buffer and CGRAM shadow against what was last sent to
+// pert: unchanged lines are skipped entirely, contiguous runs of changed
+// cells on a line are coalesced into a single PrintAt, and only CGRAM slots
+// whose Lines differ are re-uploaded.
+func (s *Screen) Flush(pert *PertelianX2040) error {
+	for slot := 0; slot < 7; slot++ {
+		if !s.slotsUsed[slot] {
+			continue
+		}
+		if s.primed && s.flushedUsed[slot] && s.flushedSlots[slot] == s.slots[slot] {
+			continue
+		}
+		if err := pert.SetCharacter(uint8(slot), s.slots[slot]); err != nil {
+			return err
+		}
+	}
+
+	for line := 0; line < canvasRows; line++ {
+		if s.primed && s.buffer[line] == s.flushedBuffer[line] {
+			continue
+		}
+		col := 0
+		for col < canvasCols {
+			if s.primed && s.buffer[line][col] == s.flushedBuffer[line][col] {
+				col++
+				continue
+			}
+			start := col
+			run := make([]byte, 0, canvasCols-col)
+			for col < canvasCols && (!s.primed || s.buffer[line][col] != s.flushedBuffer[line][col]) {
+				run = append(run, s.buffer[line][col])
+				col++
+			}
+			if err := pert.PrintAt(uint8(line), uint8(start), string(run)); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.flushedBuffer = s.buffer
+	s.flushedSlots = s.slots
+	s.flushedUsed = s.slotsUsed
+	s.primed = true
+	return nil
+}